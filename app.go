@@ -1,14 +1,12 @@
 package main
 
 import (
-	"bytes"
 	"context"
 	"fmt"
 	"os"
 	"os/exec"
 	"path/filepath"
 	"runtime"
-	"time"
 
 	wailsRuntime "github.com/wailsapp/wails/v2/pkg/runtime"
 )
@@ -41,6 +39,10 @@ func NewApp() *App {
 // startup is called when the app starts
 func (a *App) startup(ctx context.Context) {
 	a.ctx = ctx
+
+	if _, err := a.GetSettings(); err != nil {
+		debugLog("加载设置失败: %s", err)
+	}
 }
 
 // Greet returns a greeting for the given name
@@ -154,121 +156,6 @@ func (a *App) OpenLogFile() error {
 
 
 
-// RunBackend executes the backend with arguments.
-// Returns BackendResult with separate stdout/stderr so frontend can handle them independently.
-func (a *App) RunBackend(args []string) (*BackendResult, error) {
-	var cmd *exec.Cmd
-
-	// On Windows, write --patterns value to a temp file to avoid CLI encoding issues with Chinese characters.
-	// Replace --patterns <value> with --patterns-file <tempfile> in the args.
-	var tempPatternsFile string
-	if runtime.GOOS == "windows" {
-		for i := 0; i < len(args)-1; i++ {
-			if args[i] == "--patterns" {
-				patternsValue := args[i+1]
-				tmpFile, err := os.CreateTemp("", "epub-patterns-*.txt")
-				if err == nil {
-					_, writeErr := tmpFile.WriteString(patternsValue)
-					tmpFile.Close()
-					if writeErr == nil {
-						tempPatternsFile = tmpFile.Name()
-						args[i] = "--patterns-file"
-						args[i+1] = tempPatternsFile
-					} else {
-						os.Remove(tmpFile.Name())
-					}
-				}
-				break
-			}
-		}
-	}
-	// Clean up temp file when done
-	if tempPatternsFile != "" {
-		defer os.Remove(tempPatternsFile)
-	}
-
-	binaryPath := a.findBackendBinary()
-
-	cwd, _ := os.Getwd()
-	debugLog("Current working directory: %s", cwd)
-	debugLog("Binary path found: %s", binaryPath)
-
-	if binaryPath != "" {
-		cmd = exec.Command(binaryPath, args...)
-	} else {
-		ex, _ := os.Executable()
-		exPath := filepath.Dir(ex)
-		root := projectRoot()
-
-		searchPaths := []string{
-			filepath.Join("backend-py", "main.py"),
-			filepath.Join(exPath, "backend-py", "main.py"),
-			filepath.Join(root, "backend-py", "main.py"),
-		}
-
-		pythonScript := ""
-		for _, p := range searchPaths {
-			if _, err := os.Stat(p); err == nil {
-				pythonScript = p
-				break
-			}
-		}
-
-		if pythonScript == "" {
-			return nil, fmt.Errorf("后端程序未找到: 既没有编译的二进制文件，也没有 Python 脚本\n搜索路径: %v", searchPaths)
-		}
-
-		debugLog("Using Python script: %s", pythonScript)
-		cmdArgs := append([]string{pythonScript}, args...)
-		pythonCmd := "python3"
-		if runtime.GOOS == "windows" {
-			pythonCmd = "python"
-		}
-		cmd = exec.Command(pythonCmd, cmdArgs...)
-	}
-
-	// Ensure UTF-8 encoding for subprocess (critical for Chinese characters in regex patterns on Windows)
-	cmd.Env = append(os.Environ(), "PYTHONIOENCODING=utf-8", "PYTHONUTF8=1")
-
-	var stdout bytes.Buffer
-	var stderr bytes.Buffer
-	cmd.Stdout = &stdout
-	cmd.Stderr = &stderr
-
-	// 启动进程
-	if err := cmd.Start(); err != nil {
-		return &BackendResult{}, fmt.Errorf("启动后端失败: %s", err)
-	}
-
-	// 带超时等待，默认 5 分钟
-	done := make(chan error, 1)
-	go func() {
-		done <- cmd.Wait()
-	}()
-
-	timeout := 5 * time.Minute
-	var err error
-	select {
-	case <-time.After(timeout):
-		if cmd.Process != nil {
-			cmd.Process.Kill()
-		}
-		err = fmt.Errorf("执行超时（5分钟）")
-	case err = <-done:
-	}
-
-	result := &BackendResult{
-		Stdout: stdout.String(),
-		Stderr: stderr.String(),
-	}
-
-	if err != nil {
-		return result, fmt.Errorf("执行失败: %s\nSTDERR: %s", err, result.Stderr)
-	}
-
-	return result, nil
-}
-
 // SelectFile opens a file dialog for selecting a file
 func (a *App) SelectFile() (string, error) {
 	return wailsRuntime.OpenFileDialog(a.ctx, wailsRuntime.OpenDialogOptions{
@@ -319,7 +206,7 @@ func (a *App) OpenURL(url string) error {
 // Returns JSON string with search results.
 func (a *App) SearchDoubanCover(title string) (string, error) {
 	args := []string{"--plugin", "txt2epub", "--txt-path", "/dev/null", "--epub-path", "/dev/null", "--title", "search", "--search-cover", title}
-	result, err := a.RunBackend(args)
+	result, err := a.RunBackend(nextJobID(), args, 30)
 	if err != nil {
 		return "", fmt.Errorf("搜索封面失败: %s", err)
 	}
@@ -329,7 +216,7 @@ func (a *App) SearchDoubanCover(title string) (string, error) {
 // DownloadDoubanCover downloads a cover image from URL and returns the local path.
 func (a *App) DownloadDoubanCover(coverURL string) (string, error) {
 	args := []string{"--plugin", "txt2epub", "--txt-path", "/dev/null", "--epub-path", "/dev/null", "--title", "download", "--download-cover", coverURL}
-	result, err := a.RunBackend(args)
+	result, err := a.RunBackend(nextJobID(), args, 30)
 	if err != nil {
 		return "", fmt.Errorf("下载封面失败: %s", err)
 	}
@@ -0,0 +1,289 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	wailsRuntime "github.com/wailsapp/wails/v2/pkg/runtime"
+)
+
+// ProgressFrame is a structured progress line emitted by the backend on
+// stdout, e.g. {"type":"progress","job":"1","stage":"convert","percent":42,"message":"..."}.
+type ProgressFrame struct {
+	Type    string `json:"type"`
+	Job     string `json:"job"`
+	Stage   string `json:"stage"`
+	Percent int    `json:"percent"`
+	Message string `json:"message"`
+}
+
+// runningCmds tracks in-flight backend processes by job id so they can be
+// cancelled from the frontend.
+var (
+	runningCmds   = map[string]*exec.Cmd{}
+	runningCmdsMu sync.Mutex
+	jobCounter    int64
+)
+
+// nextJobID generates a unique job id for backend invocations that don't
+// have a caller-supplied one (e.g. the cover search helpers).
+func nextJobID() string {
+	return fmt.Sprintf("job-%d", atomic.AddInt64(&jobCounter, 1))
+}
+
+func registerCmd(jobID string, cmd *exec.Cmd) {
+	runningCmdsMu.Lock()
+	defer runningCmdsMu.Unlock()
+	runningCmds[jobID] = cmd
+}
+
+func unregisterCmd(jobID string) {
+	runningCmdsMu.Lock()
+	defer runningCmdsMu.Unlock()
+	delete(runningCmds, jobID)
+}
+
+// CancelBackend kills the backend process associated with jobID, if still running.
+func (a *App) CancelBackend(jobID string) error {
+	runningCmdsMu.Lock()
+	cmd, ok := runningCmds[jobID]
+	runningCmdsMu.Unlock()
+
+	if !ok || cmd.Process == nil {
+		return fmt.Errorf("任务不存在或已结束: %s", jobID)
+	}
+	return cmd.Process.Kill()
+}
+
+// RunBackend executes the backend with arguments, streaming progress to the
+// frontend as it runs. jobID identifies the invocation for backend:progress /
+// backend:log / backend:started / backend:finished events and for
+// CancelBackend. timeoutSeconds <= 0 means no timeout.
+// Returns BackendResult with the full accumulated stdout/stderr once the
+// process exits, so callers that don't care about streaming can still use
+// the return value directly.
+func (a *App) RunBackend(jobID string, args []string, timeoutSeconds int) (*BackendResult, error) {
+	var cmd *exec.Cmd
+
+	// On Windows, write --patterns value to a temp file to avoid CLI encoding issues with Chinese characters.
+	// Replace --patterns <value> with --patterns-file <tempfile> in the args.
+	var tempPatternsFile string
+	if runtime.GOOS == "windows" {
+		for i := 0; i < len(args)-1; i++ {
+			if args[i] == "--patterns" {
+				patternsValue := args[i+1]
+				tmpFile, err := os.CreateTemp("", "epub-patterns-*.txt")
+				if err == nil {
+					_, writeErr := tmpFile.WriteString(patternsValue)
+					tmpFile.Close()
+					if writeErr == nil {
+						tempPatternsFile = tmpFile.Name()
+						args[i] = "--patterns-file"
+						args[i+1] = tempPatternsFile
+					} else {
+						os.Remove(tmpFile.Name())
+					}
+				}
+				break
+			}
+		}
+	}
+	// Clean up temp file when done
+	if tempPatternsFile != "" {
+		defer os.Remove(tempPatternsFile)
+	}
+
+	binaryPath := a.findBackendBinary()
+
+	cwd, _ := os.Getwd()
+	debugLog("Current working directory: %s", cwd)
+	debugLog("Binary path found: %s", binaryPath)
+
+	if binaryPath != "" {
+		cmd = exec.Command(binaryPath, args...)
+	} else {
+		ex, _ := os.Executable()
+		exPath := filepath.Dir(ex)
+		root := projectRoot()
+
+		searchPaths := []string{
+			filepath.Join("backend-py", "main.py"),
+			filepath.Join(exPath, "backend-py", "main.py"),
+			filepath.Join(root, "backend-py", "main.py"),
+		}
+
+		pythonScript := ""
+		for _, p := range searchPaths {
+			if _, err := os.Stat(p); err == nil {
+				pythonScript = p
+				break
+			}
+		}
+
+		if pythonScript == "" {
+			return nil, fmt.Errorf("后端程序未找到: 既没有编译的二进制文件，也没有 Python 脚本\n搜索路径: %v", searchPaths)
+		}
+
+		debugLog("Using Python script: %s", pythonScript)
+		cmdArgs := append([]string{pythonScript}, args...)
+		pythonCmd := "python3"
+		if runtime.GOOS == "windows" {
+			pythonCmd = "python"
+		}
+		cmd = exec.Command(pythonCmd, cmdArgs...)
+	}
+
+	// Ensure UTF-8 encoding for subprocess (critical for Chinese characters in regex patterns on Windows)
+	cmd.Env = append(os.Environ(), "PYTHONIOENCODING=utf-8", "PYTHONUTF8=1")
+
+	return a.runStreamed(jobID, cmd, timeoutSeconds)
+}
+
+// runStreamed starts cmd, streaming its stdout/stderr to the frontend as
+// backend:progress / backend:log events under jobID, registers it with
+// CancelBackend for the duration of the run, and enforces timeoutSeconds
+// (<= 0 means no timeout). It's shared by RunBackend and ConvertFormat so
+// every child process the app spawns gets the same progress/cancel/timeout
+// behavior.
+func (a *App) runStreamed(jobID string, cmd *exec.Cmd, timeoutSeconds int) (*BackendResult, error) {
+	stdoutPipe, err := cmd.StdoutPipe()
+	if err != nil {
+		return &BackendResult{}, fmt.Errorf("创建输出管道失败: %s", err)
+	}
+	stderrPipe, err := cmd.StderrPipe()
+	if err != nil {
+		return &BackendResult{}, fmt.Errorf("创建错误管道失败: %s", err)
+	}
+
+	var stdout, stderr bytes.Buffer
+	var streamErr error
+	var streamErrMu sync.Mutex
+	recordStreamErr := func(err error) {
+		streamErrMu.Lock()
+		defer streamErrMu.Unlock()
+		if streamErr == nil {
+			streamErr = err
+		}
+		// The pipe stopped being drained; if the child is still alive it can
+		// block forever writing to a full stdout/stderr buffer, so kill it
+		// rather than leaving RunBackend to hang when timeoutSeconds is 0.
+		if cmd.Process != nil {
+			cmd.Process.Kill()
+		}
+	}
+
+	var wg sync.WaitGroup
+	wg.Add(2)
+	go a.streamOutput(jobID, stdoutPipe, &stdout, &wg, recordStreamErr)
+	go a.streamOutput(jobID, stderrPipe, &stderr, &wg, recordStreamErr)
+
+	if err := cmd.Start(); err != nil {
+		return &BackendResult{}, fmt.Errorf("启动子进程失败: %s", err)
+	}
+	registerCmd(jobID, cmd)
+	defer unregisterCmd(jobID)
+
+	wailsRuntime.EventsEmit(a.ctx, "backend:started", jobID)
+
+	done := make(chan error, 1)
+	go func() {
+		wg.Wait()
+		err := cmd.Wait()
+		streamErrMu.Lock()
+		if streamErr != nil {
+			if err != nil {
+				err = fmt.Errorf("%s（进程退出: %s）", streamErr, err)
+			} else {
+				err = streamErr
+			}
+		}
+		streamErrMu.Unlock()
+		done <- err
+	}()
+
+	var waitErr error
+	if timeoutSeconds > 0 {
+		timeout := time.Duration(timeoutSeconds) * time.Second
+		select {
+		case <-time.After(timeout):
+			if cmd.Process != nil {
+				cmd.Process.Kill()
+			}
+			waitErr = fmt.Errorf("执行超时（%d 秒）", timeoutSeconds)
+			<-done
+		case waitErr = <-done:
+		}
+	} else {
+		waitErr = <-done
+	}
+
+	result := &BackendResult{
+		Stdout: stdout.String(),
+		Stderr: stderr.String(),
+	}
+
+	wailsRuntime.EventsEmit(a.ctx, "backend:finished", map[string]interface{}{
+		"job":   jobID,
+		"error": errString(waitErr),
+	})
+
+	if waitErr != nil {
+		return result, fmt.Errorf("执行失败: %s\nSTDERR: %s", waitErr, result.Stderr)
+	}
+
+	return result, nil
+}
+
+// streamOutput reads lines from r, accumulating them into acc, and forwards
+// recognized JSON progress frames as backend:progress events (plain lines as
+// backend:log events). If the scanner stops early due to a real error
+// (rather than a clean EOF) - e.g. a single line over the 1MB buffer cap -
+// onErr is called so the caller can surface it instead of treating the
+// command as having finished cleanly.
+func (a *App) streamOutput(jobID string, r io.Reader, acc *bytes.Buffer, wg *sync.WaitGroup, onErr func(error)) {
+	defer wg.Done()
+
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 0, 64*1024), 1024*1024)
+	for scanner.Scan() {
+		line := scanner.Text()
+		acc.WriteString(line)
+		acc.WriteByte('\n')
+
+		var frame ProgressFrame
+		if err := json.Unmarshal([]byte(line), &frame); err == nil && frame.Type == "progress" {
+			if frame.Job == "" {
+				frame.Job = jobID
+			}
+			wailsRuntime.EventsEmit(a.ctx, "backend:progress", frame)
+			continue
+		}
+
+		wailsRuntime.EventsEmit(a.ctx, "backend:log", map[string]string{
+			"job":  jobID,
+			"line": line,
+		})
+	}
+
+	if err := scanner.Err(); err != nil {
+		debugLog("读取子进程输出失败 (job %s): %s", jobID, err)
+		onErr(fmt.Errorf("读取输出失败: %w", err))
+	}
+}
+
+func errString(err error) string {
+	if err == nil {
+		return ""
+	}
+	return err.Error()
+}
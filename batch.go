@@ -0,0 +1,253 @@
+package main
+
+import (
+	"fmt"
+	"runtime"
+	"sync"
+
+	wailsRuntime "github.com/wailsapp/wails/v2/pkg/runtime"
+)
+
+// BatchItem describes a single input file to run through a backend plugin,
+// plus the args template to invoke it with. "{input}" in Args is replaced
+// with InputPath before the backend is invoked.
+type BatchItem struct {
+	InputPath string   `json:"inputPath"`
+	Plugin    string   `json:"plugin"`
+	Args      []string `json:"args"`
+}
+
+// BatchItemStatus is the per-item snapshot sent to the frontend via
+// batch:item events and returned by GetBatchStatus.
+type BatchItemStatus struct {
+	Index  int    `json:"index"`
+	Status string `json:"status"` // queued|running|success|error
+	Output string `json:"output"`
+	Error  string `json:"error"`
+}
+
+// BatchStatus is the full snapshot of a batch job returned by GetBatchStatus.
+type BatchStatus struct {
+	JobID     string            `json:"jobId"`
+	Items     []BatchItemStatus `json:"items"`
+	Paused    bool              `json:"paused"`
+	Cancelled bool              `json:"cancelled"`
+	Done      bool              `json:"done"`
+}
+
+// batchJob tracks the live state of one EnqueueBatch call.
+type batchJob struct {
+	id    string
+	items []BatchItem
+
+	mu        sync.Mutex
+	cond      *sync.Cond
+	status    []BatchItemStatus
+	paused    bool
+	cancelled bool
+	done      bool
+}
+
+var (
+	batchJobs   = map[string]*batchJob{}
+	batchJobsMu sync.Mutex
+)
+
+func registerBatchJob(job *batchJob) {
+	batchJobsMu.Lock()
+	defer batchJobsMu.Unlock()
+	batchJobs[job.id] = job
+}
+
+func lookupBatchJob(jobID string) (*batchJob, error) {
+	batchJobsMu.Lock()
+	defer batchJobsMu.Unlock()
+	job, ok := batchJobs[jobID]
+	if !ok {
+		return nil, fmt.Errorf("批量任务不存在: %s", jobID)
+	}
+	return job, nil
+}
+
+// EnqueueBatch starts processing items with a worker pool sized by
+// runtime.NumCPU() (overridable via Settings.MaxBatchWorkers), and returns a
+// job id that can be used with PauseBatch/ResumeBatch/CancelBatch/GetBatchStatus.
+func (a *App) EnqueueBatch(items []BatchItem) (string, error) {
+	if len(items) == 0 {
+		return "", fmt.Errorf("批量任务没有任何条目")
+	}
+
+	job := &batchJob{
+		id:     nextJobID(),
+		items:  items,
+		status: make([]BatchItemStatus, len(items)),
+	}
+	job.cond = sync.NewCond(&job.mu)
+	for i := range job.status {
+		job.status[i] = BatchItemStatus{Index: i, Status: "queued"}
+	}
+	registerBatchJob(job)
+
+	go a.runBatch(job)
+
+	return job.id, nil
+}
+
+// PauseBatch suspends processing of queued (not already running) items.
+func (a *App) PauseBatch(jobID string) error {
+	job, err := lookupBatchJob(jobID)
+	if err != nil {
+		return err
+	}
+	job.mu.Lock()
+	job.paused = true
+	job.mu.Unlock()
+	return nil
+}
+
+// ResumeBatch resumes a previously paused batch job.
+func (a *App) ResumeBatch(jobID string) error {
+	job, err := lookupBatchJob(jobID)
+	if err != nil {
+		return err
+	}
+	job.mu.Lock()
+	job.paused = false
+	job.mu.Unlock()
+	job.cond.Broadcast()
+	return nil
+}
+
+// CancelBatch stops a batch job; items already running are left to finish,
+// queued items are marked as errored.
+func (a *App) CancelBatch(jobID string) error {
+	job, err := lookupBatchJob(jobID)
+	if err != nil {
+		return err
+	}
+	job.mu.Lock()
+	job.cancelled = true
+	job.mu.Unlock()
+	job.cond.Broadcast()
+	return nil
+}
+
+// GetBatchStatus returns a snapshot of the batch job's current state.
+func (a *App) GetBatchStatus(jobID string) (*BatchStatus, error) {
+	job, err := lookupBatchJob(jobID)
+	if err != nil {
+		return nil, err
+	}
+
+	job.mu.Lock()
+	defer job.mu.Unlock()
+
+	items := make([]BatchItemStatus, len(job.status))
+	copy(items, job.status)
+
+	return &BatchStatus{
+		JobID:     job.id,
+		Items:     items,
+		Paused:    job.paused,
+		Cancelled: job.cancelled,
+		Done:      job.done,
+	}, nil
+}
+
+func (job *batchJob) setStatus(idx int, status, output, errMsg string) BatchItemStatus {
+	job.mu.Lock()
+	job.status[idx].Status = status
+	job.status[idx].Output = output
+	job.status[idx].Error = errMsg
+	s := job.status[idx]
+	job.mu.Unlock()
+	return s
+}
+
+func (job *batchJob) waitWhilePaused() {
+	job.mu.Lock()
+	for job.paused && !job.cancelled {
+		job.cond.Wait()
+	}
+	job.mu.Unlock()
+}
+
+func (job *batchJob) isCancelled() bool {
+	job.mu.Lock()
+	defer job.mu.Unlock()
+	return job.cancelled
+}
+
+// runBatch drives a worker pool over job.items, emitting a batch:item event
+// for every status transition.
+func (a *App) runBatch(job *batchJob) {
+	workers := runtime.NumCPU()
+	if s, err := a.GetSettings(); err == nil && s.MaxBatchWorkers > 0 {
+		workers = s.MaxBatchWorkers
+	}
+	if workers > len(job.items) {
+		workers = len(job.items)
+	}
+
+	indexCh := make(chan int, len(job.items))
+	for i := range job.items {
+		indexCh <- i
+	}
+	close(indexCh)
+
+	var wg sync.WaitGroup
+	for w := 0; w < workers; w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for idx := range indexCh {
+				job.waitWhilePaused()
+				if job.isCancelled() {
+					a.emitBatchItem(job.id, job.setStatus(idx, "error", "", "任务已取消"))
+					continue
+				}
+				a.runBatchItem(job, idx)
+			}
+		}()
+	}
+	wg.Wait()
+
+	job.mu.Lock()
+	job.done = true
+	job.mu.Unlock()
+}
+
+func (a *App) runBatchItem(job *batchJob, idx int) {
+	item := job.items[idx]
+	a.emitBatchItem(job.id, job.setStatus(idx, "running", "", ""))
+
+	args := make([]string, 0, len(item.Args)+2)
+	if item.Plugin != "" {
+		args = append(args, "--plugin", item.Plugin)
+	}
+	for _, arg := range item.Args {
+		if arg == "{input}" {
+			args = append(args, item.InputPath)
+		} else {
+			args = append(args, arg)
+		}
+	}
+
+	itemJobID := fmt.Sprintf("%s:%d", job.id, idx)
+	result, err := a.RunBackend(itemJobID, args, 0)
+	if err != nil {
+		a.emitBatchItem(job.id, job.setStatus(idx, "error", "", err.Error()))
+		return
+	}
+	a.emitBatchItem(job.id, job.setStatus(idx, "success", result.Stdout, ""))
+}
+
+func (a *App) emitBatchItem(jobID string, item BatchItemStatus) {
+	wailsRuntime.EventsEmit(a.ctx, "batch:item", map[string]interface{}{
+		"jobID":  jobID,
+		"index":  item.Index,
+		"status": item.Status,
+		"output": item.Output,
+		"error":  item.Error,
+	})
+}
@@ -0,0 +1,106 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+	"strings"
+)
+
+// calibreFallbackPaths returns OS-specific locations to check for Calibre's
+// ebook-convert when it isn't on PATH.
+func calibreFallbackPaths() []string {
+	switch runtime.GOOS {
+	case "darwin":
+		return []string{"/Applications/calibre.app/Contents/MacOS/ebook-convert"}
+	case "windows":
+		paths := []string{}
+		for _, envVar := range []string{"ProgramFiles", "ProgramFiles(x86)"} {
+			if dir := os.Getenv(envVar); dir != "" {
+				paths = append(paths, filepath.Join(dir, "Calibre2", "ebook-convert.exe"))
+			}
+		}
+		return paths
+	default:
+		return nil
+	}
+}
+
+// findCalibreBinary locates ebook-convert via PATH, falling back to the
+// common per-OS install locations.
+func findCalibreBinary() string {
+	if path, err := exec.LookPath("ebook-convert"); err == nil {
+		return path
+	}
+	for _, p := range calibreFallbackPaths() {
+		if _, err := os.Stat(p); err == nil {
+			return p
+		}
+	}
+	return ""
+}
+
+// DetectCalibre locates the ebook-convert binary and reports its version,
+// caching the discovered path in settings for ConvertFormat to reuse.
+// DetectCalibre itself always re-scans and does not read the cached path.
+func (a *App) DetectCalibre() (string, string, error) {
+	path := findCalibreBinary()
+	if path == "" {
+		return "", "", fmt.Errorf("未检测到 Calibre，请安装后重试")
+	}
+
+	out, err := exec.Command(path, "--version").Output()
+	version := ""
+	if err == nil {
+		version = strings.TrimSpace(string(out))
+	}
+
+	if s, err := a.GetSettings(); err == nil {
+		s.CalibrePath = path
+		_ = a.SetSettings(s)
+	}
+
+	return path, version, nil
+}
+
+// ConvertFormat converts inputEpub to each of targets (e.g. "mobi", "azw3",
+// "pdf", "docx") via Calibre's ebook-convert, writing the results into
+// outDir. Progress for each target is streamed through the same mechanism
+// as RunBackend. Returns the paths of the files that were produced.
+func (a *App) ConvertFormat(inputEpub string, targets []string, outDir string) ([]string, error) {
+	binaryPath := ""
+	if s, err := a.GetSettings(); err == nil && s.CalibrePath != "" {
+		if _, statErr := os.Stat(s.CalibrePath); statErr == nil {
+			binaryPath = s.CalibrePath
+		}
+	}
+	if binaryPath == "" {
+		binaryPath = findCalibreBinary()
+	}
+	if binaryPath == "" {
+		return nil, fmt.Errorf("未检测到 Calibre，请安装后重试")
+	}
+
+	if err := os.MkdirAll(outDir, 0o755); err != nil {
+		return nil, fmt.Errorf("创建输出目录失败: %s", err)
+	}
+
+	base := strings.TrimSuffix(filepath.Base(inputEpub), filepath.Ext(inputEpub))
+
+	var outputs []string
+	for _, target := range targets {
+		ext := strings.ToLower(strings.TrimPrefix(target, "."))
+		outputPath := filepath.Join(outDir, base+"."+ext)
+
+		cmd := exec.Command(binaryPath, inputEpub, outputPath)
+		jobID := fmt.Sprintf("calibre-%s-%s", ext, nextJobID())
+		if _, err := a.runStreamed(jobID, cmd, 0); err != nil {
+			return outputs, fmt.Errorf("转换为 %s 失败: %s", ext, err)
+		}
+		outputs = append(outputs, outputPath)
+	}
+
+	return outputs, nil
+}
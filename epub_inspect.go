@@ -0,0 +1,41 @@
+package main
+
+import (
+	"github.com/wangyyyqw/epub/pkg/epubx"
+)
+
+// EpubInfo is the JSON-friendly inspection result returned to the frontend.
+type EpubInfo struct {
+	Metadata epubx.Metadata `json:"metadata"`
+	Spine    []string       `json:"spine"`
+}
+
+// MetadataPatch is the JSON-friendly counterpart of epubx.MetadataPatch.
+type MetadataPatch struct {
+	Title   string `json:"title"`
+	Creator string `json:"creator"`
+}
+
+// EpubInspect reads spine and metadata from path without shelling out to the
+// Python backend.
+func (a *App) EpubInspect(path string) (*EpubInfo, error) {
+	info, err := epubx.Inspect(path)
+	if err != nil {
+		return nil, err
+	}
+	return &EpubInfo{Metadata: info.Metadata, Spine: info.Spine}, nil
+}
+
+// EpubSetMetadata patches title/creator on the EPUB at path in place.
+func (a *App) EpubSetMetadata(path string, meta MetadataPatch) error {
+	return epubx.SetMetadata(path, epubx.MetadataPatch{
+		Title:   meta.Title,
+		Creator: meta.Creator,
+	})
+}
+
+// EpubReplaceCover swaps the cover image of the EPUB at path with the image
+// at imagePath, in place.
+func (a *App) EpubReplaceCover(path, imagePath string) error {
+	return epubx.ReplaceCover(path, imagePath)
+}
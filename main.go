@@ -35,6 +35,7 @@ func main() {
 		Bind: []interface{}{
 			app,
 		},
+		Menu: buildMenu(app),
 		Mac: &mac.Options{
 			TitleBar: &mac.TitleBar{
 				TitlebarAppearsTransparent: true,
@@ -0,0 +1,133 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/wailsapp/wails/v2/pkg/menu"
+	"github.com/wailsapp/wails/v2/pkg/menu/keys"
+	wailsRuntime "github.com/wailsapp/wails/v2/pkg/runtime"
+)
+
+// Version is the application version, overridden at build time via
+// -ldflags "-X main.Version=x.y.z".
+var Version = "1.0.2"
+
+const releaseAPIURL = "https://api.github.com/repos/wangyyyqw/epub/releases/latest"
+
+// releaseInfo mirrors the fields we care about from the GitHub releases API.
+type releaseInfo struct {
+	TagName string `json:"tag_name"`
+	HTMLURL string `json:"html_url"`
+}
+
+// buildMenu constructs the native application menu, wiring each item to the
+// corresponding bound method on app.
+func buildMenu(app *App) *menu.Menu {
+	appMenu := menu.NewMenu()
+
+	fileMenu := appMenu.AddSubmenu("文件")
+	fileMenu.AddText("打开 EPUB...", keys.CmdOrCtrl("o"), func(_ *menu.CallbackData) {
+		if _, err := app.SelectFiles(); err != nil {
+			debugLog("菜单打开 EPUB 失败: %s", err)
+		}
+	})
+	fileMenu.AddText("打开目录...", keys.CmdOrCtrl("shift+o"), func(_ *menu.CallbackData) {
+		if _, err := app.SelectDirectory(); err != nil {
+			debugLog("菜单打开目录失败: %s", err)
+		}
+	})
+	fileMenu.AddText("另存为...", keys.CmdOrCtrl("s"), func(_ *menu.CallbackData) {
+		if _, err := app.SaveFile(""); err != nil {
+			debugLog("菜单另存为失败: %s", err)
+		}
+	})
+
+	toolsMenu := appMenu.AddSubmenu("工具")
+	toolsMenu.AddText("打开日志文件", keys.CmdOrCtrl("l"), func(_ *menu.CallbackData) {
+		if err := app.OpenLogFile(); err != nil {
+			debugLog("菜单打开日志文件失败: %s", err)
+		}
+	})
+
+	helpMenu := appMenu.AddSubmenu("帮助")
+	helpMenu.AddText("关于", nil, func(_ *menu.CallbackData) {
+		wailsRuntime.MessageDialog(app.ctx, wailsRuntime.MessageDialogOptions{
+			Type:    wailsRuntime.InfoDialog,
+			Title:   "关于 EPUB 工具箱",
+			Message: fmt.Sprintf("EPUB 工具箱 v%s\n一站式 EPUB 电子书处理工具", Version),
+		})
+	})
+	helpMenu.AddText("检查更新", nil, func(_ *menu.CallbackData) {
+		checkForUpdates(app)
+	})
+
+	return appMenu
+}
+
+// checkForUpdates queries the latest GitHub release and, if its tag differs
+// from Version, offers to open the release page in the user's browser. Tags
+// are compared for equality only, so a release tag older than Version is
+// reported the same way as a newer one.
+func checkForUpdates(app *App) {
+	client := http.Client{Timeout: 10 * time.Second}
+	resp, err := client.Get(releaseAPIURL)
+	if err != nil {
+		wailsRuntime.MessageDialog(app.ctx, wailsRuntime.MessageDialogOptions{
+			Type:    wailsRuntime.ErrorDialog,
+			Title:   "检查更新失败",
+			Message: fmt.Sprintf("无法连接到更新服务器: %s", err),
+		})
+		return
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		wailsRuntime.MessageDialog(app.ctx, wailsRuntime.MessageDialogOptions{
+			Type:    wailsRuntime.ErrorDialog,
+			Title:   "检查更新失败",
+			Message: fmt.Sprintf("更新服务器返回错误状态: %s", resp.Status),
+		})
+		return
+	}
+
+	var release releaseInfo
+	if err := json.NewDecoder(resp.Body).Decode(&release); err != nil {
+		wailsRuntime.MessageDialog(app.ctx, wailsRuntime.MessageDialogOptions{
+			Type:    wailsRuntime.ErrorDialog,
+			Title:   "检查更新失败",
+			Message: fmt.Sprintf("解析更新信息失败: %s", err),
+		})
+		return
+	}
+
+	if release.TagName == "" || release.TagName == "v"+Version || release.TagName == Version {
+		wailsRuntime.MessageDialog(app.ctx, wailsRuntime.MessageDialogOptions{
+			Type:    wailsRuntime.InfoDialog,
+			Title:   "检查更新",
+			Message: "当前已是最新版本。",
+		})
+		return
+	}
+
+	choice, err := wailsRuntime.MessageDialog(app.ctx, wailsRuntime.MessageDialogOptions{
+		Type:          wailsRuntime.QuestionDialog,
+		Title:         "发现新版本",
+		Message:       fmt.Sprintf("发现新版本 %s（当前 v%s），是否前往下载？", release.TagName, Version),
+		Buttons:       []string{"前往下载", "取消"},
+		DefaultButton: "前往下载",
+		CancelButton:  "取消",
+	})
+	if err != nil {
+		debugLog("更新对话框出错: %s", err)
+		return
+	}
+
+	if choice == "前往下载" && release.HTMLURL != "" {
+		if err := app.OpenURL(release.HTMLURL); err != nil {
+			debugLog("打开发布页面失败: %s", err)
+		}
+	}
+}
@@ -0,0 +1,608 @@
+// Package epubx provides lightweight, in-process read/write access to EPUB
+// files for operations that don't need the full Python conversion backend:
+// inspecting the spine/metadata and patching metadata or the cover image.
+package epubx
+
+import (
+	"archive/zip"
+	"bytes"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"os"
+	"path"
+	"path/filepath"
+	"regexp"
+	"strings"
+)
+
+// containerXML mirrors META-INF/container.xml, which points at the OPF
+// (package document) that describes the rest of the EPUB.
+type containerXML struct {
+	XMLName   xml.Name `xml:"container"`
+	Rootfiles struct {
+		Rootfile []struct {
+			FullPath string `xml:"full-path,attr"`
+		} `xml:"rootfile"`
+	} `xml:"rootfiles"`
+}
+
+// Metadata is the subset of Dublin Core metadata fields callers care about.
+type Metadata struct {
+	Title      string `xml:"title"`
+	Creator    string `xml:"creator"`
+	Language   string `xml:"language"`
+	Identifier string `xml:"identifier"`
+	CoverID    string `xml:"-"`
+}
+
+type opfMeta struct {
+	Name    string `xml:"name,attr"`
+	Content string `xml:"content,attr"`
+}
+
+type manifestItem struct {
+	ID         string `xml:"id,attr"`
+	Href       string `xml:"href,attr"`
+	MediaType  string `xml:"media-type,attr"`
+	Properties string `xml:"properties,attr"`
+}
+
+type spineItemRef struct {
+	IDRef string `xml:"idref,attr"`
+}
+
+// Package mirrors the parts of an OPF package document we read and patch.
+type Package struct {
+	XMLName xml.Name `xml:"package"`
+	Meta    struct {
+		Title      []string  `xml:"title"`
+		Creator    []string  `xml:"creator"`
+		Language   []string  `xml:"language"`
+		Identifier []string  `xml:"identifier"`
+		Meta       []opfMeta `xml:"meta"`
+	} `xml:"metadata"`
+	Manifest struct {
+		Items []manifestItem `xml:"item"`
+	} `xml:"manifest"`
+	Spine struct {
+		ItemRefs []spineItemRef `xml:"itemref"`
+	} `xml:"spine"`
+}
+
+// EpubInfo is the inspection result returned by Inspect.
+type EpubInfo struct {
+	OPFPath  string
+	Metadata Metadata
+	Spine    []string // manifest hrefs, in spine order
+	Manifest []manifestItem
+}
+
+// MetadataPatch describes the metadata fields to overwrite; empty fields are
+// left unchanged.
+type MetadataPatch struct {
+	Title   string
+	Creator string
+}
+
+// findOPFPath reads META-INF/container.xml from r and returns the path to
+// the package document (OPF) within the zip.
+func findOPFPath(r *zip.Reader) (string, error) {
+	f, err := r.Open("META-INF/container.xml")
+	if err != nil {
+		return "", fmt.Errorf("读取 container.xml 失败: %w", err)
+	}
+	defer f.Close()
+
+	data, err := io.ReadAll(f)
+	if err != nil {
+		return "", fmt.Errorf("读取 container.xml 失败: %w", err)
+	}
+
+	var c containerXML
+	if err := xml.Unmarshal(data, &c); err != nil {
+		return "", fmt.Errorf("解析 container.xml 失败: %w", err)
+	}
+	if len(c.Rootfiles.Rootfile) == 0 {
+		return "", fmt.Errorf("container.xml 中没有 rootfile")
+	}
+	return c.Rootfiles.Rootfile[0].FullPath, nil
+}
+
+func readPackage(r *zip.Reader, opfPath string) (*Package, error) {
+	f, err := r.Open(opfPath)
+	if err != nil {
+		return nil, fmt.Errorf("读取 OPF 失败: %w", err)
+	}
+	defer f.Close()
+
+	data, err := io.ReadAll(f)
+	if err != nil {
+		return nil, fmt.Errorf("读取 OPF 失败: %w", err)
+	}
+
+	var pkg Package
+	if err := xml.Unmarshal(data, &pkg); err != nil {
+		return nil, fmt.Errorf("解析 OPF 失败: %w", err)
+	}
+	return &pkg, nil
+}
+
+func first(values []string) string {
+	if len(values) == 0 {
+		return ""
+	}
+	return values[0]
+}
+
+// coverID resolves the manifest id of the cover image, checking the legacy
+// EPUB2 <meta name="cover" content="..."> pointer first and falling back to
+// the EPUB3 manifest item properties="cover-image" convention used by
+// current Calibre/Sigil output.
+func coverID(pkg *Package) string {
+	for _, m := range pkg.Meta.Meta {
+		if m.Name == "cover" {
+			return m.Content
+		}
+	}
+	for _, item := range pkg.Manifest.Items {
+		if hasProperty(item.Properties, "cover-image") {
+			return item.ID
+		}
+	}
+	return ""
+}
+
+// hasProperty reports whether the space-separated EPUB3 "properties"
+// attribute value contains token.
+func hasProperty(properties, token string) bool {
+	for _, p := range strings.Fields(properties) {
+		if p == token {
+			return true
+		}
+	}
+	return false
+}
+
+// Inspect opens the .epub at epubPath and returns its metadata, manifest
+// and spine (as manifest hrefs in reading order).
+func Inspect(epubPath string) (*EpubInfo, error) {
+	r, err := zip.OpenReader(epubPath)
+	if err != nil {
+		return nil, fmt.Errorf("打开 EPUB 失败: %w", err)
+	}
+	defer r.Close()
+
+	opfPath, err := findOPFPath(&r.Reader)
+	if err != nil {
+		return nil, err
+	}
+
+	pkg, err := readPackage(&r.Reader, opfPath)
+	if err != nil {
+		return nil, err
+	}
+
+	manifestByID := make(map[string]manifestItem, len(pkg.Manifest.Items))
+	for _, item := range pkg.Manifest.Items {
+		manifestByID[item.ID] = item
+	}
+
+	spine := make([]string, 0, len(pkg.Spine.ItemRefs))
+	for _, ref := range pkg.Spine.ItemRefs {
+		if item, ok := manifestByID[ref.IDRef]; ok {
+			spine = append(spine, item.Href)
+		}
+	}
+
+	return &EpubInfo{
+		OPFPath: opfPath,
+		Metadata: Metadata{
+			Title:      first(pkg.Meta.Title),
+			Creator:    first(pkg.Meta.Creator),
+			Language:   first(pkg.Meta.Language),
+			Identifier: first(pkg.Meta.Identifier),
+			CoverID:    coverID(pkg),
+		},
+		Spine:    spine,
+		Manifest: pkg.Manifest.Items,
+	}, nil
+}
+
+// zipEdit describes what to do with one entry while rewriting a zip: Rename
+// (empty keeps the original name), NewData (nil keeps the original bytes),
+// and Drop (omit the entry entirely, e.g. because it was renamed elsewhere).
+type zipEdit struct {
+	Rename  string
+	NewData []byte
+	Drop    bool
+}
+
+// rewriteZip copies every entry of src into a new zip written to dst,
+// calling edit for each entry so callers can rewrite, rename or drop
+// specific ones, then appends any entries in extra that weren't already
+// present in src (e.g. a cover image written under a new href).
+func rewriteZip(src *zip.Reader, dst io.Writer, edit func(name string, data []byte) (zipEdit, error), extra map[string][]byte) error {
+	zw := zip.NewWriter(dst)
+	written := make(map[string]bool, len(src.File))
+
+	for _, f := range src.File {
+		data, err := readZipFile(f)
+		if err != nil {
+			return err
+		}
+
+		e := zipEdit{}
+		if edit != nil {
+			e, err = edit(f.Name, data)
+			if err != nil {
+				return err
+			}
+		}
+		if e.Drop {
+			continue
+		}
+		if e.NewData != nil {
+			data = e.NewData
+		}
+
+		name := f.Name
+		header := f.FileHeader
+		if e.Rename != "" {
+			name = e.Rename
+			header.Name = name
+		}
+
+		w, err := zw.CreateHeader(&header)
+		if err != nil {
+			return fmt.Errorf("写入压缩包条目失败 %s: %w", name, err)
+		}
+		if _, err := w.Write(data); err != nil {
+			return fmt.Errorf("写入压缩包内容失败 %s: %w", name, err)
+		}
+		written[name] = true
+	}
+
+	for name, data := range extra {
+		if written[name] {
+			continue
+		}
+		w, err := zw.Create(name)
+		if err != nil {
+			return fmt.Errorf("写入压缩包条目失败 %s: %w", name, err)
+		}
+		if _, err := w.Write(data); err != nil {
+			return fmt.Errorf("写入压缩包内容失败 %s: %w", name, err)
+		}
+	}
+
+	return zw.Close()
+}
+
+func readZipFile(f *zip.File) ([]byte, error) {
+	rc, err := f.Open()
+	if err != nil {
+		return nil, fmt.Errorf("读取压缩包条目失败 %s: %w", f.Name, err)
+	}
+	defer rc.Close()
+	return io.ReadAll(rc)
+}
+
+// writeAtomic streams the rewritten zip to a temp file in the same
+// directory as epubPath, then renames it over the original once complete.
+func writeAtomic(epubPath string, r *zip.Reader, edit func(name string, data []byte) (zipEdit, error), extra map[string][]byte) error {
+	dir := filepath.Dir(epubPath)
+	tmp, err := os.CreateTemp(dir, "epubx-*.epub.tmp")
+	if err != nil {
+		return fmt.Errorf("创建临时文件失败: %w", err)
+	}
+	tmpPath := tmp.Name()
+
+	if err := rewriteZip(r, tmp, edit, extra); err != nil {
+		tmp.Close()
+		os.Remove(tmpPath)
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		os.Remove(tmpPath)
+		return fmt.Errorf("关闭临时文件失败: %w", err)
+	}
+
+	if err := os.Rename(tmpPath, epubPath); err != nil {
+		os.Remove(tmpPath)
+		return fmt.Errorf("替换 EPUB 文件失败: %w", err)
+	}
+	return nil
+}
+
+// SetMetadata applies patch to the OPF's title/creator fields and writes the
+// result back to epubPath atomically.
+func SetMetadata(epubPath string, patch MetadataPatch) error {
+	r, err := zip.OpenReader(epubPath)
+	if err != nil {
+		return fmt.Errorf("打开 EPUB 失败: %w", err)
+	}
+	defer r.Close()
+
+	opfPath, err := findOPFPath(&r.Reader)
+	if err != nil {
+		return err
+	}
+
+	return writeAtomic(epubPath, &r.Reader, func(name string, data []byte) (zipEdit, error) {
+		if name != opfPath {
+			return zipEdit{}, nil
+		}
+		newData, err := patchOPFMetadata(data, patch)
+		if err != nil {
+			return zipEdit{}, err
+		}
+		return zipEdit{NewData: newData}, nil
+	}, nil)
+}
+
+// patchOPFMetadata rewrites just the text of the first dc:title/dc:creator
+// elements in opfData, leaving every other byte of the document - other
+// metadata elements, namespace prefixes, package/manifest/spine attributes -
+// untouched. Unmarshalling the whole OPF into Package and re-encoding it
+// would silently drop everything Package doesn't model (dc:publisher,
+// dc:date, EPUB3 meta/property refinements, etc.) and strip namespace
+// prefixes from survivors, so metadata edits patch the raw bytes instead.
+func patchOPFMetadata(opfData []byte, patch MetadataPatch) ([]byte, error) {
+	var err error
+	if patch.Title != "" {
+		if opfData, err = replaceElementText(opfData, "title", patch.Title); err != nil {
+			return nil, err
+		}
+	}
+	if patch.Creator != "" {
+		if opfData, err = replaceElementText(opfData, "creator", patch.Creator); err != nil {
+			return nil, err
+		}
+	}
+	return opfData, nil
+}
+
+// replaceElementText finds the first element named localName (matched by
+// local name only, so it works regardless of namespace prefix) and replaces
+// its text content in place, preserving its start/end tags, attributes and
+// every other byte of the document.
+func replaceElementText(opfData []byte, localName, newText string) ([]byte, error) {
+	dec := xml.NewDecoder(bytes.NewReader(opfData))
+
+	var startTagEnd, endTagStart int64 = -1, -1
+	for {
+		offsetBefore := dec.InputOffset()
+		tok, err := dec.Token()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("解析 OPF 失败: %w", err)
+		}
+
+		switch t := tok.(type) {
+		case xml.StartElement:
+			if startTagEnd == -1 && t.Name.Local == localName {
+				startTagEnd = dec.InputOffset()
+			} else if startTagEnd != -1 && endTagStart == -1 {
+				return nil, fmt.Errorf("元素 <%s> 包含子元素，无法安全替换文本", localName)
+			}
+		case xml.EndElement:
+			if startTagEnd != -1 && endTagStart == -1 && t.Name.Local == localName {
+				endTagStart = offsetBefore
+			}
+		}
+
+		if startTagEnd != -1 && endTagStart != -1 {
+			break
+		}
+	}
+
+	if startTagEnd == -1 || endTagStart == -1 {
+		return nil, fmt.Errorf("OPF 中未找到元素: %s", localName)
+	}
+	if startTagEnd == endTagStart {
+		return nil, fmt.Errorf("元素 <%s> 是自闭合空标签，无法设置文本", localName)
+	}
+
+	var buf bytes.Buffer
+	buf.Write(opfData[:startTagEnd])
+	xml.EscapeText(&buf, []byte(newText))
+	buf.Write(opfData[endTagStart:])
+	return buf.Bytes(), nil
+}
+
+// ReplaceCover replaces the cover image entry - resolved via the legacy
+// <meta name="cover"> pointer or, failing that, the EPUB3 manifest item
+// properties="cover-image" - with the contents of imagePath, updating the
+// manifest href when the new image's extension differs from the old one.
+func ReplaceCover(epubPath, imagePath string) error {
+	imageData, err := os.ReadFile(imagePath)
+	if err != nil {
+		return fmt.Errorf("读取封面图片失败: %w", err)
+	}
+
+	r, err := zip.OpenReader(epubPath)
+	if err != nil {
+		return fmt.Errorf("打开 EPUB 失败: %w", err)
+	}
+	defer r.Close()
+
+	opfPath, err := findOPFPath(&r.Reader)
+	if err != nil {
+		return err
+	}
+	pkg, err := readPackage(&r.Reader, opfPath)
+	if err != nil {
+		return err
+	}
+
+	coverID := coverID(pkg)
+	if coverID == "" {
+		return fmt.Errorf("OPF 中未找到 cover 元数据")
+	}
+
+	var coverItem *manifestItem
+	for i := range pkg.Manifest.Items {
+		if pkg.Manifest.Items[i].ID == coverID {
+			coverItem = &pkg.Manifest.Items[i]
+			break
+		}
+	}
+	if coverItem == nil {
+		return fmt.Errorf("OPF 清单中未找到封面条目: %s", coverID)
+	}
+
+	oldHref := coverItem.Href
+	oldExt := filepath.Ext(oldHref)
+	newExt := strings.ToLower(filepath.Ext(imagePath))
+	newHref := oldHref
+	if newExt != "" && newExt != strings.ToLower(oldExt) {
+		newHref = oldHref[:len(oldHref)-len(oldExt)] + newExt
+	}
+
+	opfDir := path.Dir(opfPath)
+	oldCoverPath := path.Join(opfDir, oldHref)
+	newCoverPath := path.Join(opfDir, newHref)
+
+	extra := map[string][]byte{}
+	if newCoverPath != oldCoverPath {
+		extra[newCoverPath] = imageData
+	}
+
+	return writeAtomic(epubPath, &r.Reader, func(name string, data []byte) (zipEdit, error) {
+		switch name {
+		case opfPath:
+			if newHref == oldHref {
+				return zipEdit{}, nil
+			}
+			newData, err := patchManifestItemAttrs(data, coverID, map[string]string{
+				"href":       newHref,
+				"media-type": mediaTypeForExt(newExt),
+			})
+			if err != nil {
+				return zipEdit{}, err
+			}
+			return zipEdit{NewData: newData}, nil
+		case oldCoverPath:
+			if newCoverPath != oldCoverPath {
+				// Old cover entry is being renamed away; the new entry is
+				// written separately under newCoverPath via extra.
+				return zipEdit{Drop: true}, nil
+			}
+			return zipEdit{NewData: imageData}, nil
+		case newCoverPath:
+			// An entry already exists under the new href; overwrite it here,
+			// since rewriteZip skips extra entries whose name was written.
+			return zipEdit{NewData: imageData}, nil
+		}
+		return zipEdit{}, nil
+	}, extra)
+}
+
+// patchManifestItemAttrs rewrites the given attributes on the <item id="itemID">
+// manifest element in place, leaving its other attributes (including
+// properties=, which Package/manifestItem would otherwise round-trip lossily)
+// and the rest of the document untouched.
+func patchManifestItemAttrs(opfData []byte, itemID string, attrs map[string]string) ([]byte, error) {
+	dec := xml.NewDecoder(bytes.NewReader(opfData))
+
+	for {
+		offsetBefore := dec.InputOffset()
+		tok, err := dec.Token()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("解析 OPF 失败: %w", err)
+		}
+
+		se, ok := tok.(xml.StartElement)
+		if !ok || se.Name.Local != "item" || attrValue(se, "id") != itemID {
+			continue
+		}
+
+		tagEnd := dec.InputOffset()
+		tag := opfData[offsetBefore:tagEnd]
+		for name, value := range attrs {
+			tag = setTagAttr(tag, name, value)
+		}
+
+		var buf bytes.Buffer
+		buf.Write(opfData[:offsetBefore])
+		buf.Write(tag)
+		buf.Write(opfData[tagEnd:])
+		return buf.Bytes(), nil
+	}
+
+	return nil, fmt.Errorf("OPF 清单中未找到条目: %s", itemID)
+}
+
+func attrValue(se xml.StartElement, localName string) string {
+	for _, a := range se.Attr {
+		if a.Name.Local == localName {
+			return a.Value
+		}
+	}
+	return ""
+}
+
+// setTagAttr replaces the value of attrName within a single raw start-tag's
+// bytes (e.g. `<item id="x" href="old.png"/>`), leaving every other
+// attribute and the tag's formatting untouched. If attrName isn't already
+// present, it's inserted before the tag's closing "/>" or ">".
+func setTagAttr(tag []byte, attrName, value string) []byte {
+	escaped := escapeXMLAttr(value)
+	replacement := fmt.Sprintf(`%s="%s"`, attrName, escaped)
+
+	// The leading \s is captured (not just \b) so attrName can't match a
+	// suffix of a longer attribute, e.g. "href" inside "xlink:href" - ':' is
+	// a non-word rune, so \b alone would false-match right after it.
+	re := regexp.MustCompile(fmt.Sprintf(`(\s)%s\s*=\s*(?:"[^"]*"|'[^']*')`, regexp.QuoteMeta(attrName)))
+	if loc := re.FindSubmatchIndex(tag); loc != nil {
+		var buf bytes.Buffer
+		buf.Write(tag[:loc[3]]) // up to and including the captured leading whitespace
+		buf.WriteString(replacement)
+		buf.Write(tag[loc[1]:])
+		return buf.Bytes()
+	}
+
+	insertion := []byte(" " + replacement)
+	if idx := bytes.LastIndex(tag, []byte("/>")); idx != -1 {
+		var buf bytes.Buffer
+		buf.Write(tag[:idx])
+		buf.Write(insertion)
+		buf.Write(tag[idx:])
+		return buf.Bytes()
+	}
+	if idx := bytes.LastIndex(tag, []byte(">")); idx != -1 {
+		var buf bytes.Buffer
+		buf.Write(tag[:idx])
+		buf.Write(insertion)
+		buf.Write(tag[idx:])
+		return buf.Bytes()
+	}
+	return append(tag, insertion...)
+}
+
+func escapeXMLAttr(s string) string {
+	var buf bytes.Buffer
+	xml.EscapeText(&buf, []byte(s))
+	return buf.String()
+}
+
+func mediaTypeForExt(ext string) string {
+	switch ext {
+	case ".png":
+		return "image/png"
+	case ".jpg", ".jpeg":
+		return "image/jpeg"
+	case ".gif":
+		return "image/gif"
+	case ".webp":
+		return "image/webp"
+	default:
+		return "application/octet-stream"
+	}
+}
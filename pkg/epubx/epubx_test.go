@@ -0,0 +1,231 @@
+package epubx
+
+import (
+	"archive/zip"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestReplaceElementTextPreservesNamespaceAndSiblings(t *testing.T) {
+	opf := []byte(`<?xml version="1.0"?>
+<package xmlns="http://www.idpf.org/2007/opf" xmlns:dc="http://purl.org/dc/elements/1.1/" unique-identifier="bookid">
+  <metadata>
+    <dc:title>Old Title</dc:title>
+    <dc:creator>Old Author</dc:creator>
+    <dc:publisher>Acme Press</dc:publisher>
+    <meta property="dcterms:modified">2020-01-01T00:00:00Z</meta>
+  </metadata>
+</package>`)
+
+	out, err := replaceElementText(opf, "title", "New Title")
+	if err != nil {
+		t.Fatalf("replaceElementText: %v", err)
+	}
+	got := string(out)
+
+	if !strings.Contains(got, "<dc:title>New Title</dc:title>") {
+		t.Errorf("title was not replaced, got: %s", got)
+	}
+	if !strings.Contains(got, "<dc:creator>Old Author</dc:creator>") {
+		t.Errorf("unrelated dc:creator element was touched, got: %s", got)
+	}
+	if !strings.Contains(got, "<dc:publisher>Acme Press</dc:publisher>") {
+		t.Errorf("dc:publisher was dropped, got: %s", got)
+	}
+	if !strings.Contains(got, `<meta property="dcterms:modified">2020-01-01T00:00:00Z</meta>`) {
+		t.Errorf("EPUB3 meta refinement was dropped, got: %s", got)
+	}
+}
+
+func TestReplaceElementTextSelfClosingErrors(t *testing.T) {
+	opf := []byte(`<package><metadata><dc:title/></metadata></package>`)
+	if _, err := replaceElementText(opf, "title", "New"); err == nil {
+		t.Fatal("expected an error for a self-closing element, got nil")
+	}
+}
+
+func TestReplaceElementTextMissingErrors(t *testing.T) {
+	opf := []byte(`<package><metadata></metadata></package>`)
+	if _, err := replaceElementText(opf, "title", "New"); err == nil {
+		t.Fatal("expected an error for a missing element, got nil")
+	}
+}
+
+func TestPatchManifestItemAttrsPreservesOtherAttrsAndSuffixes(t *testing.T) {
+	opf := []byte(`<package><manifest>` +
+		`<item id="cover" xlink:href="wrong.png" href="old.png" media-type="image/png" properties="cover-image"/>` +
+		`<item id="chap1" href="chap1.xhtml" media-type="application/xhtml+xml"/>` +
+		`</manifest></package>`)
+
+	out, err := patchManifestItemAttrs(opf, "cover", map[string]string{
+		"href":       "new.jpg",
+		"media-type": "image/jpeg",
+	})
+	if err != nil {
+		t.Fatalf("patchManifestItemAttrs: %v", err)
+	}
+	got := string(out)
+
+	if !strings.Contains(got, `href="new.jpg"`) {
+		t.Errorf("href was not updated, got: %s", got)
+	}
+	if !strings.Contains(got, `media-type="image/jpeg"`) {
+		t.Errorf("media-type was not updated, got: %s", got)
+	}
+	if !strings.Contains(got, `xlink:href="wrong.png"`) {
+		t.Errorf("unrelated xlink:href attribute was clobbered, got: %s", got)
+	}
+	if !strings.Contains(got, `properties="cover-image"`) {
+		t.Errorf("properties attribute was dropped, got: %s", got)
+	}
+	if !strings.Contains(got, `id="chap1" href="chap1.xhtml"`) {
+		t.Errorf("unrelated manifest item was touched, got: %s", got)
+	}
+}
+
+func TestSetMetadataAndReplaceCoverRoundTrip(t *testing.T) {
+	containerXML := []byte(`<?xml version="1.0"?>
+<container><rootfiles><rootfile full-path="OEBPS/content.opf" media-type="application/oebps-package+xml"/></rootfiles></container>`)
+
+	opf := []byte(`<?xml version="1.0"?>
+<package xmlns="http://www.idpf.org/2007/opf" xmlns:dc="http://purl.org/dc/elements/1.1/" version="3.0" unique-identifier="bookid">
+  <metadata>
+    <dc:title>Old Title</dc:title>
+    <dc:creator>Old Author</dc:creator>
+    <dc:publisher>Acme Press</dc:publisher>
+  </metadata>
+  <manifest>
+    <item id="cover-image" href="images/cover.png" media-type="image/png" properties="cover-image"/>
+    <item id="chap1" href="chap1.xhtml" media-type="application/xhtml+xml"/>
+  </manifest>
+  <spine>
+    <itemref idref="chap1"/>
+  </spine>
+</package>`)
+
+	epubPath := writeTestEpub(t, map[string][]byte{
+		"META-INF/container.xml": containerXML,
+		"OEBPS/content.opf":      opf,
+		"OEBPS/images/cover.png": []byte("oldpngbytes"),
+		"OEBPS/chap1.xhtml":      []byte("<html></html>"),
+	})
+
+	if err := SetMetadata(epubPath, MetadataPatch{Title: "New Title"}); err != nil {
+		t.Fatalf("SetMetadata: %v", err)
+	}
+
+	info, err := Inspect(epubPath)
+	if err != nil {
+		t.Fatalf("Inspect after SetMetadata: %v", err)
+	}
+	if info.Metadata.Title != "New Title" {
+		t.Errorf("Title = %q, want %q", info.Metadata.Title, "New Title")
+	}
+	if info.Metadata.Creator != "Old Author" {
+		t.Errorf("Creator changed unexpectedly: %q", info.Metadata.Creator)
+	}
+	assertOPFContains(t, epubPath, "OEBPS/content.opf", "<dc:publisher>Acme Press</dc:publisher>")
+
+	newCoverFile := filepath.Join(t.TempDir(), "cover.JPG")
+	if err := os.WriteFile(newCoverFile, []byte("newjpgbytes"), 0o644); err != nil {
+		t.Fatalf("write fixture cover: %v", err)
+	}
+
+	if err := ReplaceCover(epubPath, newCoverFile); err != nil {
+		t.Fatalf("ReplaceCover: %v", err)
+	}
+
+	info, err = Inspect(epubPath)
+	if err != nil {
+		t.Fatalf("Inspect after ReplaceCover: %v", err)
+	}
+	if info.Metadata.CoverID != "cover-image" {
+		t.Errorf("CoverID changed unexpectedly: %q", info.Metadata.CoverID)
+	}
+
+	if data := readZipEntry(t, epubPath, "OEBPS/images/cover.jpg"); string(data) != "newjpgbytes" {
+		t.Errorf("new cover bytes = %q, want %q", data, "newjpgbytes")
+	}
+	if hasZipEntry(t, epubPath, "OEBPS/images/cover.png") {
+		t.Error("old cover.png entry should have been removed after the href rename")
+	}
+	assertOPFContains(t, epubPath, "OEBPS/content.opf", `href="images/cover.jpg"`)
+	assertOPFContains(t, epubPath, "OEBPS/content.opf", `media-type="image/jpeg"`)
+}
+
+func writeTestEpub(t *testing.T, files map[string][]byte) string {
+	t.Helper()
+
+	epubPath := filepath.Join(t.TempDir(), "book.epub")
+	f, err := os.Create(epubPath)
+	if err != nil {
+		t.Fatalf("create fixture epub: %v", err)
+	}
+	defer f.Close()
+
+	zw := zip.NewWriter(f)
+	for name, data := range files {
+		w, err := zw.Create(name)
+		if err != nil {
+			t.Fatalf("create zip entry %s: %v", name, err)
+		}
+		if _, err := w.Write(data); err != nil {
+			t.Fatalf("write zip entry %s: %v", name, err)
+		}
+	}
+	if err := zw.Close(); err != nil {
+		t.Fatalf("close fixture epub: %v", err)
+	}
+	return epubPath
+}
+
+func readZipEntry(t *testing.T, epubPath, name string) []byte {
+	t.Helper()
+
+	r, err := zip.OpenReader(epubPath)
+	if err != nil {
+		t.Fatalf("open %s: %v", epubPath, err)
+	}
+	defer r.Close()
+
+	f, err := r.Open(name)
+	if err != nil {
+		t.Fatalf("open entry %s: %v", name, err)
+	}
+	defer f.Close()
+
+	data, err := io.ReadAll(f)
+	if err != nil {
+		t.Fatalf("read entry %s: %v", name, err)
+	}
+	return data
+}
+
+func hasZipEntry(t *testing.T, epubPath, name string) bool {
+	t.Helper()
+
+	r, err := zip.OpenReader(epubPath)
+	if err != nil {
+		t.Fatalf("open %s: %v", epubPath, err)
+	}
+	defer r.Close()
+
+	for _, f := range r.File {
+		if f.Name == name {
+			return true
+		}
+	}
+	return false
+}
+
+func assertOPFContains(t *testing.T, epubPath, opfName, substr string) {
+	t.Helper()
+
+	data := readZipEntry(t, epubPath, opfName)
+	if !strings.Contains(string(data), substr) {
+		t.Errorf("OPF missing expected content %q; got:\n%s", substr, data)
+	}
+}
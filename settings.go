@@ -0,0 +1,165 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+)
+
+const (
+	settingsDirName  = "epub-toolbox"
+	settingsFileName = "settings.json"
+	maxRecentFiles   = 20
+)
+
+// Settings holds persisted user preferences so the app doesn't start fresh
+// on every launch.
+type Settings struct {
+	LastInputDir         string   `json:"lastInputDir"`
+	LastOutputDir        string   `json:"lastOutputDir"`
+	DefaultPatterns      []string `json:"defaultPatterns"`
+	PreferredCoverSource string   `json:"preferredCoverSource"`
+	Theme                string   `json:"theme"`
+	RecentFiles          []string `json:"recentFiles"`
+	WindowWidth          int      `json:"windowWidth"`
+	WindowHeight         int      `json:"windowHeight"`
+	MaxBatchWorkers      int      `json:"maxBatchWorkers"`
+	CalibrePath          string   `json:"calibrePath"`
+}
+
+var (
+	settingsMu     sync.Mutex
+	cachedSettings *Settings
+)
+
+// settingsFilePath returns the OS-appropriate path for the settings file.
+func settingsFilePath() (string, error) {
+	configDir, err := os.UserConfigDir()
+	if err != nil {
+		return "", fmt.Errorf("无法定位配置目录: %s", err)
+	}
+	return filepath.Join(configDir, settingsDirName, settingsFileName), nil
+}
+
+// loadSettings reads settings from disk, returning sane defaults if the
+// file does not exist yet.
+func loadSettings() (*Settings, error) {
+	path, err := settingsFilePath()
+	if err != nil {
+		return nil, err
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return &Settings{}, nil
+		}
+		return nil, fmt.Errorf("读取设置文件失败: %s", err)
+	}
+
+	var s Settings
+	if err := json.Unmarshal(data, &s); err != nil {
+		return nil, fmt.Errorf("解析设置文件失败: %s", err)
+	}
+	return &s, nil
+}
+
+// saveSettings writes s to disk atomically (temp file + rename).
+func saveSettings(s *Settings) error {
+	path, err := settingsFilePath()
+	if err != nil {
+		return err
+	}
+
+	dir := filepath.Dir(path)
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return fmt.Errorf("创建配置目录失败: %s", err)
+	}
+
+	data, err := json.MarshalIndent(s, "", "  ")
+	if err != nil {
+		return fmt.Errorf("序列化设置失败: %s", err)
+	}
+
+	tmpFile, err := os.CreateTemp(dir, "settings-*.json.tmp")
+	if err != nil {
+		return fmt.Errorf("创建临时设置文件失败: %s", err)
+	}
+	tmpPath := tmpFile.Name()
+
+	if _, err := tmpFile.Write(data); err != nil {
+		tmpFile.Close()
+		os.Remove(tmpPath)
+		return fmt.Errorf("写入临时设置文件失败: %s", err)
+	}
+	if err := tmpFile.Close(); err != nil {
+		os.Remove(tmpPath)
+		return fmt.Errorf("关闭临时设置文件失败: %s", err)
+	}
+
+	if err := os.Rename(tmpPath, path); err != nil {
+		os.Remove(tmpPath)
+		return fmt.Errorf("替换设置文件失败: %s", err)
+	}
+	return nil
+}
+
+// GetSettings returns the currently persisted user preferences.
+func (a *App) GetSettings() (*Settings, error) {
+	settingsMu.Lock()
+	defer settingsMu.Unlock()
+
+	if cachedSettings != nil {
+		return cachedSettings, nil
+	}
+
+	s, err := loadSettings()
+	if err != nil {
+		return nil, err
+	}
+	cachedSettings = s
+	return cachedSettings, nil
+}
+
+// SetSettings persists s, replacing any previously stored preferences.
+func (a *App) SetSettings(s *Settings) error {
+	settingsMu.Lock()
+	defer settingsMu.Unlock()
+
+	if err := saveSettings(s); err != nil {
+		return err
+	}
+	cachedSettings = s
+	return nil
+}
+
+// RecentFilesAdd records path as the most recently used file, trimming the
+// list to maxRecentFiles and removing any earlier duplicate.
+func (a *App) RecentFilesAdd(path string) error {
+	settingsMu.Lock()
+	defer settingsMu.Unlock()
+
+	if cachedSettings == nil {
+		s, err := loadSettings()
+		if err != nil {
+			return err
+		}
+		cachedSettings = s
+	}
+
+	recent := make([]string, 0, len(cachedSettings.RecentFiles)+1)
+	recent = append(recent, path)
+	for _, p := range cachedSettings.RecentFiles {
+		if p != path {
+			recent = append(recent, p)
+		}
+	}
+	if len(recent) > maxRecentFiles {
+		recent = recent[:maxRecentFiles]
+	}
+	cachedSettings.RecentFiles = recent
+
+	return saveSettings(cachedSettings)
+}